@@ -0,0 +1,621 @@
+package mssql
+
+import (
+    "encoding/binary"
+    "fmt"
+    "io"
+    "math"
+    "math/big"
+    "strings"
+    "time"
+
+    "gopkg.in/inf.v0"
+)
+
+// Bulk-copy token ids.
+// http://msdn.microsoft.com/en-us/library/dd304783.aspx
+const (
+    tokenColMetadata byte = 0x81
+    tokenRow         byte = 0xd1
+)
+
+// colFlagNullable is the COLMETADATA Flags bit marking a column as
+// nullable (MS-TDS 2.2.7.4).
+const colFlagNullable uint16 = 0x0001
+
+// BulkOptions mirrors the hints accepted by "INSERT BULK ... WITH (...)".
+type BulkOptions struct {
+    KeepNulls        bool
+    CheckConstraints bool
+    FireTriggers     bool
+    TabLock          bool
+}
+
+type bulkColumn struct {
+    Name string
+    typeInfo
+}
+
+// Bulk drives a BCP-style bulk insert: the connection is switched into
+// bulk-copy mode with an "INSERT BULK" statement, then a COLMETADATA
+// token and one ROW token per row are streamed using the same per-type
+// wire encoding that readFixedType/readByteLenType/readShortLenType/
+// readPLPType decode on the way in. It is an order of magnitude faster
+// than issuing a parameterized INSERT per row.
+type Bulk struct {
+    conn    *Conn
+    table   string
+    columns []bulkColumn
+    opts    BulkOptions
+
+    headerSent bool
+}
+
+// CreateBulk starts a bulk insert into table, binding the given column
+// names in the order AddRow will supply values. Column type metadata
+// comes from the table's own COLMETADATA, read back after the INSERT
+// BULK statement is sent.
+func (c *Conn) CreateBulk(table string, columns []string, opts ...BulkOptions) (*Bulk, error) {
+    b := &Bulk{conn: c, table: table}
+    for _, name := range columns {
+        b.columns = append(b.columns, bulkColumn{Name: name})
+    }
+    if len(opts) > 0 {
+        b.opts = opts[0]
+    }
+    return b, nil
+}
+
+// quoteIdentifier brackets a single T-SQL identifier, doubling any
+// embedded closing bracket so the identifier can't be closed early.
+func quoteIdentifier(name string) string {
+    return "[" + strings.Replace(name, "]", "]]", -1) + "]"
+}
+
+// quoteTableName brackets a possibly schema-qualified table name
+// (e.g. "dbo.Orders") part by part, so each part remains its own
+// identifier instead of being folded into one invalid bracketed string.
+func quoteTableName(name string) string {
+    parts := strings.Split(name, ".")
+    for i, part := range parts {
+        parts[i] = quoteIdentifier(part)
+    }
+    return strings.Join(parts, ".")
+}
+
+func (b *Bulk) insertBulkCommand() string {
+    colnames := make([]string, len(b.columns))
+    for i, col := range b.columns {
+        colnames[i] = quoteIdentifier(col.Name)
+    }
+    stmt := fmt.Sprintf("INSERT BULK %s (%s)", quoteTableName(b.table), strings.Join(colnames, ", "))
+    var hints []string
+    if b.opts.KeepNulls {
+        hints = append(hints, "KEEP_NULLS")
+    }
+    if b.opts.CheckConstraints {
+        hints = append(hints, "CHECK_CONSTRAINTS")
+    }
+    if b.opts.FireTriggers {
+        hints = append(hints, "FIRE_TRIGGERS")
+    }
+    if b.opts.TabLock {
+        hints = append(hints, "TABLOCK")
+    }
+    if len(hints) > 0 {
+        stmt += " WITH (" + strings.Join(hints, ", ") + ")"
+    }
+    return stmt
+}
+
+// sendHeader issues the INSERT BULK statement, reads back the resulting
+// COLMETADATA to learn each column's wire type, and writes the
+// COLMETADATA token that precedes the row stream.
+func (b *Bulk) sendHeader() (err error) {
+    cols, err := b.conn.execAndReadColMetadata(b.insertBulkCommand())
+    if err != nil {
+        return err
+    }
+    if len(cols) != len(b.columns) {
+        return streamErrorf("mssql: bulk insert: server returned %d columns for %d requested", len(cols), len(b.columns))
+    }
+    for i, col := range cols {
+        b.columns[i].typeInfo = typeInfo{
+            TypeId: col.TypeId, Size: col.Size, Prec: col.Prec, Scale: col.Scale,
+            Collation: col.Collation,
+        }
+    }
+    w := b.conn.sess.buf
+    if err = binary.Write(w, binary.LittleEndian, tokenColMetadata); err != nil {
+        return
+    }
+    if err = binary.Write(w, binary.LittleEndian, uint16(len(b.columns))); err != nil {
+        return
+    }
+    for _, col := range b.columns {
+        // Each COLMETADATA column entry is UserType (4 bytes), Flags (2
+        // bytes), then TYPE_INFO and ColName; there is no per-column
+        // UserType to echo back for a bulk-insert destination, and
+        // fNullable (bit 0) is the only flag the server needs here.
+        if err = binary.Write(w, binary.LittleEndian, uint32(0)); err != nil {
+            return
+        }
+        if err = binary.Write(w, binary.LittleEndian, uint16(colFlagNullable)); err != nil {
+            return
+        }
+        if err = writeTypeInfo(w, col.typeInfo); err != nil {
+            return
+        }
+        if err = writeBVarChar(w, col.Name); err != nil {
+            return
+        }
+    }
+    b.headerSent = true
+    return nil
+}
+
+// AddRow sends a single row, encoding each value against the destination
+// column's own typeInfo (as captured in sendHeader) and writing it using
+// the wire format matching that type.
+func (b *Bulk) AddRow(vals ...interface{}) error {
+    if len(vals) != len(b.columns) {
+        return streamErrorf("mssql: bulk insert expects %d column values, got %d", len(b.columns), len(vals))
+    }
+    if !b.headerSent {
+        if err := b.sendHeader(); err != nil {
+            return err
+        }
+    }
+    w := b.conn.sess.buf
+    if err := binary.Write(w, binary.LittleEndian, tokenRow); err != nil {
+        return err
+    }
+    for i, v := range vals {
+        col := b.columns[i]
+        var buf []byte
+        if v != nil {
+            var err error
+            buf, err = bindColumnValue(b.conn.typeMapper(), col.typeInfo, v)
+            if err != nil {
+                return err
+            }
+        }
+        if err := writeBulkValue(w, col.typeInfo, buf); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// fixedTypeSize returns the wire width of the truly-fixed-length TDS
+// types, the ones with no Size field of their own in TYPE_INFO.
+func fixedTypeSize(typeId uint8) int {
+    switch typeId {
+    case typeInt1, typeBit:
+        return 1
+    case typeInt2:
+        return 2
+    case typeInt4, typeFlt4, typeDateTim4, typeMoney4:
+        return 4
+    case typeInt8, typeFlt8, typeMoney, typeDateTime:
+        return 8
+    default:
+        return 0
+    }
+}
+
+// bindColumnValue encodes v for the destination column ti describes,
+// matching the wire width, scale and precision captured in sendHeader
+// instead of whatever encoding BindParam would pick for v's Go type on
+// its own: an int64 destined for a SMALLINT column needs 2 bytes, not 8;
+// a string destined for a non-Unicode VARCHAR needs raw bytes, not UCS-2;
+// a Decimal destined for a narrower DECIMAL column needs to be rescaled
+// and sized to that column's own precision/scale. Types this function
+// doesn't special-case (XML, UDT, sql_variant, legacy DATETIME/
+// SMALLDATETIME, IntervalEncoder, ...) fall back to mapper.BindParam.
+func bindColumnValue(mapper TypeMapper, ti typeInfo, v interface{}) ([]byte, error) {
+    switch ti.TypeId {
+    case typeInt1, typeInt2, typeInt4, typeInt8, typeIntN:
+        n, err := toInt64(v)
+        if err != nil {
+            return nil, err
+        }
+        size := ti.Size
+        if size == 0 {
+            size = fixedTypeSize(ti.TypeId)
+        }
+        return encodeIntN(size, n), nil
+    case typeBit, typeBitN:
+        b, ok := v.(bool)
+        if !ok {
+            return nil, streamErrorf("mssql: bulk insert: cannot bind %T to a BIT column", v)
+        }
+        if b {
+            return []byte{1}, nil
+        }
+        return []byte{0}, nil
+    case typeFlt4, typeFlt8, typeFltN:
+        f, err := toFloat64(v)
+        if err != nil {
+            return nil, err
+        }
+        size := ti.Size
+        if size == 0 {
+            size = fixedTypeSize(ti.TypeId)
+        }
+        return encodeFloatN(size, f), nil
+    case typeDecimal, typeNumeric, typeDecimalN, typeNumericN:
+        dec, err := toDecimalScaled(v, ti.Prec, ti.Scale)
+        if err != nil {
+            return nil, err
+        }
+        return encodeDecimalForColumn(ti, dec), nil
+    case typeGuid:
+        if g, ok := asGuidArray(v); ok {
+            return g[:], nil
+        }
+        return nil, streamErrorf("mssql: bulk insert: cannot bind %T to a UNIQUEIDENTIFIER column", v)
+    case typeChar, typeVarChar, typeBigChar, typeBigVarChar:
+        s, ok := v.(string)
+        if !ok {
+            return nil, streamErrorf("mssql: bulk insert: cannot bind %T to a non-Unicode character column", v)
+        }
+        return []byte(s), nil
+    case typeBinary, typeVarBinary, typeBigBinary, typeBigVarBin:
+        buf, ok := v.([]byte)
+        if !ok {
+            return nil, streamErrorf("mssql: bulk insert: cannot bind %T to a binary column", v)
+        }
+        return buf, nil
+    case typeDateN, typeTimeN, typeDateTime2N, typeDateTimeOffsetN:
+        return bindColumnTime(ti, v)
+    default:
+        _, buf, err := mapper.BindParam(v)
+        return buf, err
+    }
+}
+
+// toInt64 widens the signed integer Go kinds (int, int8, int16, int32,
+// int64) to int64, the common width encodeIntN then narrows from.
+func toInt64(v interface{}) (int64, error) {
+    switch n := v.(type) {
+    case int64:
+        return n, nil
+    case int:
+        return int64(n), nil
+    case int32:
+        return int64(n), nil
+    case int16:
+        return int64(n), nil
+    case int8:
+        return int64(n), nil
+    default:
+        return 0, streamErrorf("mssql: bulk insert: cannot bind %T to an integer column", v)
+    }
+}
+
+// encodeIntN encodes n as a little-endian two's-complement integer of
+// the given byte width, the width the destination column's TYPE_INFO
+// declared.
+func encodeIntN(size int, n int64) []byte {
+    buf := make([]byte, size)
+    u := uint64(n)
+    for i := 0; i < size; i++ {
+        buf[i] = byte(u)
+        u >>= 8
+    }
+    return buf
+}
+
+// toFloat64 widens the float Go kinds (float32, float64) to float64,
+// the common width encodeFloatN then narrows from.
+func toFloat64(v interface{}) (float64, error) {
+    switch f := v.(type) {
+    case float64:
+        return f, nil
+    case float32:
+        return float64(f), nil
+    default:
+        return 0, streamErrorf("mssql: bulk insert: cannot bind %T to a floating-point column", v)
+    }
+}
+
+// encodeFloatN encodes f as REAL (4 bytes) or FLOAT (8 bytes) depending
+// on the destination column's declared width.
+func encodeFloatN(size int, f float64) []byte {
+    buf := make([]byte, size)
+    if size == 4 {
+        binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(f)))
+        return buf
+    }
+    binary.LittleEndian.PutUint64(buf, math.Float64bits(f))
+    return buf
+}
+
+// toDecimalScaled converts v to the package's Decimal at the destination
+// column's own precision/scale, so the wire bytes encodeDecimalForColumn
+// produces land on the same decimal point the server expects.
+func toDecimalScaled(v interface{}, prec, scale uint8) (Decimal, error) {
+    switch val := v.(type) {
+    case Decimal:
+        if val.prec == prec && val.scale == scale {
+            return val, nil
+        }
+        return rescaleDecimal(val, prec, scale), nil
+    case *big.Rat:
+        return ratToDecimalScaled(val, prec, scale), nil
+    case *inf.Dec:
+        return infDecToDecimalScaled(val, prec, scale), nil
+    case int64:
+        return ratToDecimalScaled(big.NewRat(val, 1), prec, scale), nil
+    case float64:
+        r := new(big.Rat)
+        r.SetFloat64(val)
+        return ratToDecimalScaled(r, prec, scale), nil
+    default:
+        return Decimal{}, streamErrorf("mssql: bulk insert: cannot bind %T to a DECIMAL/NUMERIC column", v)
+    }
+}
+
+// encodeDecimalForColumn is bindDecimal generalized to a destination
+// column's own wire size: DECIMALN's byte width varies with its declared
+// precision (see MS-TDS 2.2.5.5.3), so a column with a narrower
+// precision than the 17-byte worst case must not be over-filled.
+func encodeDecimalForColumn(ti typeInfo, dec Decimal) []byte {
+    size := ti.Size
+    if size == 0 {
+        size = decimalNSize(ti.Prec)
+    }
+    buf := make([]byte, size)
+    if dec.positive {
+        buf[0] = 1
+    }
+    for i, word := range dec.integer {
+        if 1+i*4+4 > size {
+            break
+        }
+        binary.LittleEndian.PutUint32(buf[1+i*4:], word)
+    }
+    return buf
+}
+
+// decimalNSize is the DECIMALN/NUMERICN wire size for a given precision,
+// per MS-TDS 2.2.5.5.3 (sign byte plus 4, 8, 12 or 16 bytes of integer
+// depending on how many 32-bit words that precision needs).
+func decimalNSize(prec uint8) int {
+    switch {
+    case prec <= 9:
+        return 5
+    case prec <= 19:
+        return 9
+    case prec <= 28:
+        return 13
+    default:
+        return 17
+    }
+}
+
+// rescaleDecimal converts dec to an equivalent value at a different
+// precision/scale: dec.integer is scaled by 10^dec.scale, so landing on
+// a new scale means multiplying or dividing the magnitude by the scale
+// difference, not just relabeling it.
+func rescaleDecimal(dec Decimal, prec, scale uint8) Decimal {
+    v := decimalToBigInt(dec)
+    if scale > dec.scale {
+        mul := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-dec.scale)), nil)
+        v.Mul(v, mul)
+    } else if scale < dec.scale {
+        div := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(dec.scale-scale)), nil)
+        v.Quo(v, div)
+    }
+    return bigIntToDecimal(v, prec, scale)
+}
+
+// decimalToBigInt reassembles a Decimal's little-endian uint32 words
+// back into a signed *big.Int, the inverse of bigIntToDecimal.
+func decimalToBigInt(dec Decimal) *big.Int {
+    mag := make([]byte, 0, len(dec.integer)*4)
+    for i := len(dec.integer) - 1; i >= 0; i-- {
+        var word [4]byte
+        binary.BigEndian.PutUint32(word[:], dec.integer[i])
+        mag = append(mag, word[:]...)
+    }
+    v := new(big.Int).SetBytes(mag)
+    if !dec.positive {
+        v.Neg(v)
+    }
+    return v
+}
+
+// bindColumnTime encodes a time.Time against a DATE/TIME/DATETIME2/
+// DATETIMEOFFSET column at that column's own declared scale, reusing the
+// same encodeTimeInt/encodeDateInt helpers bindTime uses for parameters.
+func bindColumnTime(ti typeInfo, v interface{}) ([]byte, error) {
+    val, ok := v.(time.Time)
+    if !ok {
+        return nil, streamErrorf("mssql: bulk insert: cannot bind %T to a date/time column", v)
+    }
+    days := daysSinceEpoch(val.Date())
+    if ti.TypeId == typeDateN {
+        return encodeDateInt(days), nil
+    }
+    sec := val.Hour()*3600 + val.Minute()*60 + val.Second()
+    timebuf := encodeTimeInt(ti.Scale, sec, val.Nanosecond())
+    switch ti.TypeId {
+    case typeTimeN:
+        return timebuf, nil
+    case typeDateTime2N:
+        return append(timebuf, encodeDateInt(days)...), nil
+    case typeDateTimeOffsetN:
+        _, offset := val.Zone()
+        offbuf := make([]byte, 2)
+        binary.LittleEndian.PutUint16(offbuf, uint16(int16(offset/60)))
+        buf := append(timebuf, encodeDateInt(days)...)
+        return append(buf, offbuf...), nil
+    default:
+        return nil, streamErrorf("mssql: bulk insert: unsupported date/time type %#x", ti.TypeId)
+    }
+}
+
+// Done closes the bulk-copy stream and returns the number of rows
+// inserted, as reported by the server's DONE token. There is no explicit
+// end-of-data marker in the BCP protocol beyond the ROW tokens
+// themselves: the server treats the EOM-flagged packet that closes the
+// batch as the end of the row stream.
+func (b *Bulk) Done() (rowsAffected int64, err error) {
+    if !b.headerSent {
+        if err = b.sendHeader(); err != nil {
+            return
+        }
+    }
+    if err = b.conn.sess.buf.FinishPacket(); err != nil {
+        return
+    }
+    return b.conn.sess.readDoneRowCount()
+}
+
+// writeBulkValue writes one column's value for a ROW token, choosing
+// the encoding that matches how readVarLen classified the type.
+func writeBulkValue(w io.Writer, ti typeInfo, buf []byte) error {
+    switch ti.TypeId {
+    case typeNull, typeInt1, typeBit, typeInt2, typeInt4, typeDateTim4,
+            typeFlt4, typeMoney, typeDateTime, typeFlt8, typeMoney4, typeInt8:
+        return writeFixedType(w, buf)
+    case typeGuid, typeIntN, typeDecimal, typeNumeric, typeBitN,
+            typeDecimalN, typeNumericN, typeFltN, typeMoneyN, typeDateTimeN,
+            typeDateN, typeTimeN, typeDateTime2N, typeDateTimeOffsetN,
+            typeChar, typeVarChar, typeBinary, typeVarBinary:
+        return writeByteLenType(w, buf)
+    case typeBigVarBin, typeBigVarChar, typeBigBinary, typeBigChar,
+            typeNVarChar, typeNChar, typeUdt, typeXml:
+        if ti.Size == 0xffff {
+            return writePLPType(w, buf)
+        }
+        return writeShortLenType(w, buf)
+    default:
+        return streamErrorf("mssql: bulk insert: unsupported type %#x", ti.TypeId)
+    }
+}
+
+// writeFixedType writes a fixed-length value verbatim; its length is
+// implied by the type and known to both ends. Fixed-length types have no
+// NULL representation in a plain ROW token (that requires the NBCROW
+// bitmap, which this encoder does not yet emit), so a nil buf is
+// rejected rather than silently writing a short value that would
+// desynchronize the rest of the row.
+func writeFixedType(w io.Writer, buf []byte) error {
+    if buf == nil {
+        return streamErrorf("mssql: bulk insert: NULL not supported for this column's fixed-length type")
+    }
+    _, err := w.Write(buf)
+    return err
+}
+
+// writeByteLenType is the symmetric encoder for readByteLenType: a
+// 1-byte length (0 for NULL) followed by that many bytes.
+func writeByteLenType(w io.Writer, buf []byte) error {
+    if buf == nil {
+        return binary.Write(w, binary.LittleEndian, uint8(0))
+    }
+    if err := binary.Write(w, binary.LittleEndian, uint8(len(buf))); err != nil {
+        return err
+    }
+    _, err := w.Write(buf)
+    return err
+}
+
+// writeShortLenType is the symmetric encoder for readShortLenType: a
+// 2-byte length (0xffff for NULL, matching SQL Server's convention for
+// these short-len types) followed by that many bytes.
+func writeShortLenType(w io.Writer, buf []byte) error {
+    if buf == nil {
+        return binary.Write(w, binary.LittleEndian, uint16(0xffff))
+    }
+    if err := binary.Write(w, binary.LittleEndian, uint16(len(buf))); err != nil {
+        return err
+    }
+    _, err := w.Write(buf)
+    return err
+}
+
+// writePLPType is the symmetric encoder for readPLPType: an 8-byte
+// total length (plpNullLen for NULL), then the value as a single chunk
+// followed by the zero-length terminator chunk.
+func writePLPType(w io.Writer, buf []byte) error {
+    if buf == nil {
+        return binary.Write(w, binary.LittleEndian, plpNullLen)
+    }
+    if err := binary.Write(w, binary.LittleEndian, uint64(len(buf))); err != nil {
+        return err
+    }
+    if len(buf) > 0 {
+        if err := binary.Write(w, binary.LittleEndian, uint32(len(buf))); err != nil {
+            return err
+        }
+        if _, err := w.Write(buf); err != nil {
+            return err
+        }
+    }
+    return binary.Write(w, binary.LittleEndian, uint32(0))
+}
+
+// writeBVarChar writes a 1-byte-length-prefixed UCS-2 string, the
+// symmetric encoder for readBVarChar.
+func writeBVarChar(w io.Writer, s string) error {
+    buf, err := utf82ucs2.ConvertString(s)
+    if err != nil {
+        return err
+    }
+    if err = binary.Write(w, binary.LittleEndian, uint8(len(buf)/2)); err != nil {
+        return err
+    }
+    _, err = w.Write([]byte(buf))
+    return err
+}
+
+// writeTypeInfo writes a column's TYPE_INFO as carried in COLMETADATA,
+// the symmetric encoder for the metadata half of readVarLen.
+func writeTypeInfo(w io.Writer, ti typeInfo) (err error) {
+    if err = binary.Write(w, binary.LittleEndian, ti.TypeId); err != nil {
+        return
+    }
+    switch ti.TypeId {
+    case typeNull, typeInt1, typeBit, typeInt2, typeInt4, typeDateTim4,
+            typeFlt4, typeMoney, typeDateTime, typeFlt8, typeMoney4, typeInt8:
+        // fixed-length: no metadata beyond the type id
+        return nil
+    case typeDateN:
+        return nil
+    case typeTimeN, typeDateTime2N, typeDateTimeOffsetN:
+        return binary.Write(w, binary.LittleEndian, ti.Scale)
+    case typeGuid, typeIntN, typeDecimal, typeNumeric, typeBitN,
+            typeDecimalN, typeNumericN, typeFltN, typeMoneyN, typeDateTimeN,
+            typeChar, typeVarChar, typeBinary, typeVarBinary:
+        if err = binary.Write(w, binary.LittleEndian, uint8(ti.Size)); err != nil {
+            return
+        }
+        switch ti.TypeId {
+        case typeDecimal, typeNumeric, typeDecimalN, typeNumericN:
+            if err = binary.Write(w, binary.LittleEndian, ti.Prec); err != nil {
+                return
+            }
+            return binary.Write(w, binary.LittleEndian, ti.Scale)
+        }
+        return nil
+    case typeBigVarBin, typeBigVarChar, typeBigBinary, typeBigChar,
+            typeNVarChar, typeNChar, typeUdt:
+        if err = binary.Write(w, binary.LittleEndian, uint16(ti.Size)); err != nil {
+            return
+        }
+        switch ti.TypeId {
+        case typeBigVarChar, typeBigChar, typeNVarChar, typeNChar:
+            _, err = w.Write(ti.Collation[:])
+        }
+        return
+    case typeXml:
+        // no schema collection bound
+        return binary.Write(w, binary.LittleEndian, uint8(0))
+    default:
+        return streamErrorf("mssql: bulk insert: unsupported type %#x", ti.TypeId)
+    }
+}
@@ -0,0 +1,209 @@
+package mssql
+
+import (
+    "bytes"
+    "encoding/binary"
+    "io"
+    "math"
+    "testing"
+    "time"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+    cases := map[string]string{
+        "Orders":      "[Orders]",
+        "My Table":    "[My Table]",
+        "we]ird":      "[we]]ird]",
+    }
+    for in, want := range cases {
+        if got := quoteIdentifier(in); got != want {
+            t.Errorf("quoteIdentifier(%q) = %q, want %q", in, got, want)
+        }
+    }
+}
+
+func TestQuoteTableName(t *testing.T) {
+    cases := map[string]string{
+        "Orders":     "[Orders]",
+        "dbo.Orders": "[dbo].[Orders]",
+    }
+    for in, want := range cases {
+        if got := quoteTableName(in); got != want {
+            t.Errorf("quoteTableName(%q) = %q, want %q", in, got, want)
+        }
+    }
+}
+
+func TestInsertBulkCommand(t *testing.T) {
+    b := &Bulk{
+        table:   "dbo.Orders",
+        columns: []bulkColumn{{Name: "Id"}, {Name: "Name"}},
+        opts:    BulkOptions{KeepNulls: true, TabLock: true},
+    }
+    want := "INSERT BULK [dbo].[Orders] ([Id], [Name]) WITH (KEEP_NULLS, TABLOCK)"
+    if got := b.insertBulkCommand(); got != want {
+        t.Errorf("insertBulkCommand() = %q, want %q", got, want)
+    }
+}
+
+func TestWriteFixedTypeRejectsNull(t *testing.T) {
+    var buf bytes.Buffer
+    if err := writeFixedType(&buf, nil); err == nil {
+        t.Fatal("expected an error writing NULL for a fixed-length type")
+    }
+}
+
+// TestWriteByteLenPLPRoundTrip checks that the bulk-insert encoders
+// produce bytes their read-side counterparts can decode back to the
+// original value, for both a value and a NULL. writeShortLenType is
+// covered separately below: its NULL marker (length 0xffff, the
+// wire convention for non-MAX short-len types) isn't one
+// readShortLenType's pre-existing size==0 NULL check recognizes, so the
+// two aren't a round-trippable pair.
+func TestWriteByteLenPLPRoundTrip(t *testing.T) {
+    cases := []struct {
+        name   string
+        write  func(io.Writer, []byte) error
+        read   func(*columnStruct, io.Reader) ([]byte, error)
+    }{
+        {"ByteLen", writeByteLenType, readByteLenType},
+        {"PLP", writePLPType, readPLPType},
+    }
+    for _, c := range cases {
+        t.Run(c.name+"/value", func(t *testing.T) {
+            var buf bytes.Buffer
+            want := []byte("hello")
+            if err := c.write(&buf, want); err != nil {
+                t.Fatalf("write: %v", err)
+            }
+            column := columnStruct{Buffer: make([]byte, len(want))}
+            got, err := c.read(&column, &buf)
+            if err != nil {
+                t.Fatalf("read: %v", err)
+            }
+            if !bytes.Equal(got, want) {
+                t.Errorf("round-tripped %q, want %q", got, want)
+            }
+        })
+        t.Run(c.name+"/null", func(t *testing.T) {
+            var buf bytes.Buffer
+            if err := c.write(&buf, nil); err != nil {
+                t.Fatalf("write: %v", err)
+            }
+            column := columnStruct{Buffer: make([]byte, 8)}
+            got, err := c.read(&column, &buf)
+            if err != nil {
+                t.Fatalf("read: %v", err)
+            }
+            if got != nil {
+                t.Errorf("round-tripped NULL as %q", got)
+            }
+        })
+    }
+}
+
+func TestWriteShortLenType(t *testing.T) {
+    var buf bytes.Buffer
+    if err := writeShortLenType(&buf, []byte("hi")); err != nil {
+        t.Fatalf("write: %v", err)
+    }
+    want := []byte{2, 0, 'h', 'i'}
+    if !bytes.Equal(buf.Bytes(), want) {
+        t.Errorf("wrote %v, want %v", buf.Bytes(), want)
+    }
+
+    buf.Reset()
+    if err := writeShortLenType(&buf, nil); err != nil {
+        t.Fatalf("write NULL: %v", err)
+    }
+    want = []byte{0xff, 0xff}
+    if !bytes.Equal(buf.Bytes(), want) {
+        t.Errorf("wrote NULL as %v, want %v", buf.Bytes(), want)
+    }
+}
+
+// TestBindColumnValueSizesToDestination checks that bindColumnValue
+// encodes against the destination column's own typeInfo rather than
+// whatever width/scale BindParam would pick for the Go value's type,
+// for each class of mismatch a bulk insert can hit in practice.
+func TestBindColumnValueSizesToDestination(t *testing.T) {
+    t.Run("int64 to SMALLINT", func(t *testing.T) {
+        ti := typeInfo{TypeId: typeIntN, Size: 2}
+        buf, err := bindColumnValue(DefaultTypeMapper, ti, int64(1234))
+        if err != nil {
+            t.Fatalf("bindColumnValue: %v", err)
+        }
+        want := []byte{0xd2, 0x04}
+        if !bytes.Equal(buf, want) {
+            t.Errorf("got %v, want %v", buf, want)
+        }
+    })
+
+    t.Run("float64 to REAL", func(t *testing.T) {
+        ti := typeInfo{TypeId: typeFltN, Size: 4}
+        buf, err := bindColumnValue(DefaultTypeMapper, ti, float64(1.5))
+        if err != nil {
+            t.Fatalf("bindColumnValue: %v", err)
+        }
+        want := make([]byte, 4)
+        binary.LittleEndian.PutUint32(want, math.Float32bits(1.5))
+        if !bytes.Equal(buf, want) {
+            t.Errorf("got %v, want %v", buf, want)
+        }
+    })
+
+    t.Run("string to non-Unicode VARCHAR", func(t *testing.T) {
+        ti := typeInfo{TypeId: typeBigVarChar, Size: 10}
+        buf, err := bindColumnValue(DefaultTypeMapper, ti, "hi")
+        if err != nil {
+            t.Fatalf("bindColumnValue: %v", err)
+        }
+        if !bytes.Equal(buf, []byte("hi")) {
+            t.Errorf("got %v, want raw ASCII bytes, not UCS-2", buf)
+        }
+    })
+
+    t.Run("Decimal rescaled to narrower DECIMAL column", func(t *testing.T) {
+        src := Decimal{positive: true, prec: 38, scale: 8}
+        src.integer[0] = 123456789 // 1.23456789 at scale 8
+        ti := typeInfo{TypeId: typeDecimalN, Prec: 10, Scale: 2}
+        buf, err := bindColumnValue(DefaultTypeMapper, ti, src)
+        if err != nil {
+            t.Fatalf("bindColumnValue: %v", err)
+        }
+        if len(buf) != decimalNSize(ti.Prec) {
+            t.Fatalf("len(buf) = %d, want %d", len(buf), decimalNSize(ti.Prec))
+        }
+        got := binary.LittleEndian.Uint32(buf[1:5])
+        if got != 123 { // 1.23 at scale 2
+            t.Errorf("rescaled integer = %d, want 123", got)
+        }
+    })
+
+    t.Run("time.Time to DATETIME2 at column's own scale", func(t *testing.T) {
+        ti := typeInfo{TypeId: typeDateTime2N, Scale: 3, Size: 7}
+        buf, err := bindColumnValue(DefaultTypeMapper, ti, time.Date(2026, 7, 27, 1, 2, 3, 0, time.UTC))
+        if err != nil {
+            t.Fatalf("bindColumnValue: %v", err)
+        }
+        if len(buf) != 7 {
+            t.Fatalf("len(buf) = %d, want 7 (4-byte time + 3-byte date for scale 3)", len(buf))
+        }
+    })
+}
+
+// BenchmarkWriteBulkValue measures the per-row encode cost of the bulk
+// insert write path standing in for the 1M-row benchmark this request
+// asked for: a real server round trip isn't available in this
+// environment, but the encode path is what the driver itself spends CPU
+// on once rows are flowing.
+func BenchmarkWriteBulkValue(b *testing.B) {
+    ti := typeInfo{TypeId: typeIntN, Size: 8}
+    buf := make([]byte, 8)
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if err := writeBulkValue(io.Discard, ti, buf); err != nil {
+            b.Fatal(err)
+        }
+    }
+}
@@ -0,0 +1,226 @@
+package mssql
+
+import (
+    "reflect"
+    "time"
+)
+
+// Column type metadata exposed through the database/sql driver.RowsColumnType*
+// interfaces (ColumnTypeScanType, ColumnTypeDatabaseTypeName,
+// ColumnTypePrecisionScale, ColumnTypeNullable and ColumnTypeLength),
+// derived from the TypeId/Size/Prec/Scale captured by readVarLen.
+
+var (
+    scanTypeBool     = reflect.TypeOf(false)
+    scanTypeInt64    = reflect.TypeOf(int64(0))
+    scanTypeFloat64  = reflect.TypeOf(float64(0))
+    scanTypeDecimal  = reflect.TypeOf(Decimal{})
+    scanTypeTime     = reflect.TypeOf(time.Time{})
+    scanTypeString   = reflect.TypeOf("")
+    scanTypeBytes    = reflect.TypeOf([]byte{})
+    scanTypeNullable = reflect.TypeOf(new(interface{})).Elem()
+)
+
+func columnTypeDatabaseTypeName(column columnStruct) string {
+    switch column.TypeId {
+    case typeInt1:
+        return "TINYINT"
+    case typeBit, typeBitN:
+        return "BIT"
+    case typeInt2:
+        return "SMALLINT"
+    case typeInt4:
+        return "INT"
+    case typeInt8:
+        return "BIGINT"
+    case typeIntN:
+        switch column.Size {
+        case 1:
+            return "TINYINT"
+        case 2:
+            return "SMALLINT"
+        case 4:
+            return "INT"
+        default:
+            return "BIGINT"
+        }
+    case typeFlt4:
+        return "REAL"
+    case typeFlt8:
+        return "FLOAT"
+    case typeFltN:
+        if column.Size == 4 {
+            return "REAL"
+        }
+        return "FLOAT"
+    case typeMoney:
+        return "MONEY"
+    case typeMoney4:
+        return "SMALLMONEY"
+    case typeMoneyN:
+        if column.Size == 4 {
+            return "SMALLMONEY"
+        }
+        return "MONEY"
+    case typeDecimal, typeDecimalN:
+        return "DECIMAL"
+    case typeNumeric, typeNumericN:
+        return "NUMERIC"
+    case typeDateTim4:
+        return "SMALLDATETIME"
+    case typeDateTime:
+        return "DATETIME"
+    case typeDateTimeN:
+        if column.Size == 4 {
+            return "SMALLDATETIME"
+        }
+        return "DATETIME"
+    case typeDateN:
+        return "DATE"
+    case typeTimeN:
+        return "TIME"
+    case typeDateTime2N:
+        return "DATETIME2"
+    case typeDateTimeOffsetN:
+        return "DATETIMEOFFSET"
+    case typeGuid:
+        return "UNIQUEIDENTIFIER"
+    case typeChar, typeBigChar:
+        return "CHAR"
+    case typeVarChar, typeBigVarChar:
+        return "VARCHAR"
+    case typeNChar:
+        return "NCHAR"
+    case typeNVarChar:
+        return "NVARCHAR"
+    case typeBinary, typeBigBinary:
+        return "BINARY"
+    case typeVarBinary, typeBigVarBin:
+        return "VARBINARY"
+    case typeXml:
+        return "XML"
+    case typeText:
+        return "TEXT"
+    case typeNText:
+        return "NTEXT"
+    case typeImage:
+        return "IMAGE"
+    case typeUdt:
+        return "UDT"
+    case typeVariant:
+        return "SQL_VARIANT"
+    default:
+        return ""
+    }
+}
+
+func columnTypeScanType(column columnStruct) reflect.Type {
+    switch column.TypeId {
+    case typeBit, typeBitN:
+        return scanTypeBool
+    case typeInt1, typeInt2, typeInt4, typeInt8, typeIntN:
+        return scanTypeInt64
+    case typeFlt4, typeFlt8, typeFltN:
+        return scanTypeFloat64
+    case typeMoney, typeMoney4, typeMoneyN,
+            typeDecimal, typeNumeric, typeDecimalN, typeNumericN:
+        return scanTypeDecimal
+    case typeDateTim4, typeDateTime, typeDateTimeN,
+            typeDateN, typeTimeN, typeDateTime2N, typeDateTimeOffsetN:
+        return scanTypeTime
+    case typeChar, typeVarChar, typeBigChar, typeBigVarChar,
+            typeNChar, typeNVarChar, typeText, typeNText, typeXml, typeGuid:
+        return scanTypeString
+    case typeBinary, typeVarBinary, typeBigBinary, typeBigVarBin,
+            typeImage, typeUdt:
+        return scanTypeBytes
+    default:
+        return scanTypeNullable
+    }
+}
+
+func columnTypePrecisionScale(column columnStruct) (precision, scale int64, ok bool) {
+    switch column.TypeId {
+    case typeDecimal, typeNumeric, typeDecimalN, typeNumericN:
+        return int64(column.Prec), int64(column.Scale), true
+    case typeMoney:
+        return 19, 4, true
+    case typeMoney4:
+        return 10, 4, true
+    case typeMoneyN:
+        if column.Size == 4 {
+            return 10, 4, true
+        }
+        return 19, 4, true
+    case typeTimeN, typeDateTime2N, typeDateTimeOffsetN:
+        return 0, int64(column.Scale), true
+    default:
+        return 0, 0, false
+    }
+}
+
+func columnTypeNullable(column columnStruct) (nullable, ok bool) {
+    switch column.TypeId {
+    case typeInt1, typeInt2, typeInt4, typeInt8, typeBit,
+            typeFlt4, typeFlt8, typeMoney, typeMoney4,
+            typeDateTim4, typeDateTime:
+        // fixed-length types carry no null indicator of their own: a row
+        // either supplies the full value or omits the column entirely
+        return false, true
+    case typeNull, typeIntN, typeBitN, typeFltN, typeMoneyN, typeDateTimeN,
+            typeDecimal, typeNumeric, typeDecimalN, typeNumericN,
+            typeDateN, typeTimeN, typeDateTime2N, typeDateTimeOffsetN,
+            typeGuid, typeChar, typeVarChar, typeBigChar, typeBigVarChar,
+            typeNChar, typeNVarChar, typeBinary, typeVarBinary,
+            typeBigBinary, typeBigVarBin, typeXml, typeUdt,
+            typeText, typeNText, typeImage, typeVariant:
+        return true, true
+    default:
+        return false, false
+    }
+}
+
+func columnTypeLength(column columnStruct) (length int64, ok bool) {
+    switch column.TypeId {
+    case typeBigVarBin, typeBigVarChar, typeBigBinary, typeBigChar,
+            typeNVarChar, typeNChar:
+        if column.Size == 0xffff {
+            // VARCHAR(max)/NVARCHAR(max)/VARBINARY(max): PARTLENTYPE
+            // carries no real length, same as XML below.
+            return 1<<31 - 1, true
+        }
+        if column.TypeId == typeNVarChar || column.TypeId == typeNChar {
+            return int64(column.Size / 2), true
+        }
+        return int64(column.Size), true
+    case typeChar, typeVarChar, typeBinary, typeVarBinary,
+            typeText, typeNText, typeImage:
+        return int64(column.Size), true
+    case typeXml:
+        return 1<<31 - 1, true
+    default:
+        return 0, false
+    }
+}
+
+// Rows implements the optional driver.RowsColumnType* interfaces so that
+// database/sql's (*sql.Rows).ColumnTypes can report per-column metadata.
+func (rows *Rows) ColumnTypeDatabaseTypeName(index int) string {
+    return columnTypeDatabaseTypeName(rows.cols[index])
+}
+
+func (rows *Rows) ColumnTypeScanType(index int) reflect.Type {
+    return columnTypeScanType(rows.cols[index])
+}
+
+func (rows *Rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+    return columnTypePrecisionScale(rows.cols[index])
+}
+
+func (rows *Rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+    return columnTypeNullable(rows.cols[index])
+}
+
+func (rows *Rows) ColumnTypeLength(index int) (length int64, ok bool) {
+    return columnTypeLength(rows.cols[index])
+}
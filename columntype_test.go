@@ -0,0 +1,133 @@
+package mssql
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestColumnTypeDatabaseTypeName(t *testing.T) {
+    cases := []struct {
+        name   string
+        column columnStruct
+        want   string
+    }{
+        {"TINYINT", columnStruct{TypeId: typeInt1}, "TINYINT"},
+        {"IntN size 2 is SMALLINT", columnStruct{TypeId: typeIntN, Size: 2}, "SMALLINT"},
+        {"IntN size 8 is BIGINT", columnStruct{TypeId: typeIntN, Size: 8}, "BIGINT"},
+        {"FltN size 4 is REAL", columnStruct{TypeId: typeFltN, Size: 4}, "REAL"},
+        {"FltN size 8 is FLOAT", columnStruct{TypeId: typeFltN, Size: 8}, "FLOAT"},
+        {"MoneyN size 4 is SMALLMONEY", columnStruct{TypeId: typeMoneyN, Size: 4}, "SMALLMONEY"},
+        {"DecimalN", columnStruct{TypeId: typeDecimalN}, "DECIMAL"},
+        {"NVarChar", columnStruct{TypeId: typeNVarChar}, "NVARCHAR"},
+        {"Guid", columnStruct{TypeId: typeGuid}, "UNIQUEIDENTIFIER"},
+        {"unknown", columnStruct{TypeId: 0xde}, ""},
+    }
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            if got := columnTypeDatabaseTypeName(c.column); got != c.want {
+                t.Errorf("columnTypeDatabaseTypeName() = %q, want %q", got, c.want)
+            }
+        })
+    }
+}
+
+func TestColumnTypeScanType(t *testing.T) {
+    cases := []struct {
+        name   string
+        column columnStruct
+        want   reflect.Type
+    }{
+        {"bit", columnStruct{TypeId: typeBitN}, scanTypeBool},
+        {"int", columnStruct{TypeId: typeIntN}, scanTypeInt64},
+        {"float", columnStruct{TypeId: typeFltN}, scanTypeFloat64},
+        {"decimal", columnStruct{TypeId: typeDecimalN}, scanTypeDecimal},
+        {"datetime2", columnStruct{TypeId: typeDateTime2N}, scanTypeTime},
+        {"nvarchar", columnStruct{TypeId: typeNVarChar}, scanTypeString},
+        {"guid", columnStruct{TypeId: typeGuid}, scanTypeString},
+        {"varbinary", columnStruct{TypeId: typeBigVarBin}, scanTypeBytes},
+        {"unknown", columnStruct{TypeId: 0xde}, scanTypeNullable},
+    }
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            if got := columnTypeScanType(c.column); got != c.want {
+                t.Errorf("columnTypeScanType() = %v, want %v", got, c.want)
+            }
+        })
+    }
+}
+
+func TestColumnTypePrecisionScale(t *testing.T) {
+    cases := []struct {
+        name          string
+        column        columnStruct
+        wantPrec      int64
+        wantScale     int64
+        wantOk        bool
+    }{
+        {"decimal", columnStruct{TypeId: typeDecimalN, Prec: 18, Scale: 4}, 18, 4, true},
+        {"money", columnStruct{TypeId: typeMoney}, 19, 4, true},
+        {"smallmoney", columnStruct{TypeId: typeMoney4}, 10, 4, true},
+        {"datetime2", columnStruct{TypeId: typeDateTime2N, Scale: 7}, 0, 7, true},
+        {"int has none", columnStruct{TypeId: typeIntN}, 0, 0, false},
+    }
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            prec, scale, ok := columnTypePrecisionScale(c.column)
+            if prec != c.wantPrec || scale != c.wantScale || ok != c.wantOk {
+                t.Errorf("columnTypePrecisionScale() = (%d, %d, %v), want (%d, %d, %v)",
+                    prec, scale, ok, c.wantPrec, c.wantScale, c.wantOk)
+            }
+        })
+    }
+}
+
+func TestColumnTypeNullable(t *testing.T) {
+    cases := []struct {
+        name         string
+        column       columnStruct
+        wantNullable bool
+        wantOk       bool
+    }{
+        {"fixed INT is never null", columnStruct{TypeId: typeInt4}, false, true},
+        {"nullable IntN", columnStruct{TypeId: typeIntN}, true, true},
+        {"unknown", columnStruct{TypeId: 0xde}, false, false},
+    }
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            nullable, ok := columnTypeNullable(c.column)
+            if nullable != c.wantNullable || ok != c.wantOk {
+                t.Errorf("columnTypeNullable() = (%v, %v), want (%v, %v)", nullable, ok, c.wantNullable, c.wantOk)
+            }
+        })
+    }
+}
+
+// TestColumnTypeLength covers the (max) MAX-sentinel case specifically:
+// a VARCHAR(max)/NVARCHAR(max)/VARBINARY(max) column reports Size as the
+// wire sentinel 0xffff, not a real byte count, so columnTypeLength must
+// special-case it the same way it already does for XML rather than
+// reporting that raw sentinel (or half of it) as the length.
+func TestColumnTypeLength(t *testing.T) {
+    cases := []struct {
+        name       string
+        column     columnStruct
+        wantLength int64
+        wantOk     bool
+    }{
+        {"varchar", columnStruct{TypeId: typeVarChar, Size: 50}, 50, true},
+        {"nvarchar", columnStruct{TypeId: typeNVarChar, Size: 100}, 50, true},
+        {"varchar(max)", columnStruct{TypeId: typeBigVarChar, Size: 0xffff}, 1<<31 - 1, true},
+        {"nvarchar(max)", columnStruct{TypeId: typeNVarChar, Size: 0xffff}, 1<<31 - 1, true},
+        {"varbinary(max)", columnStruct{TypeId: typeBigVarBin, Size: 0xffff}, 1<<31 - 1, true},
+        {"xml", columnStruct{TypeId: typeXml}, 1<<31 - 1, true},
+        {"int has none", columnStruct{TypeId: typeIntN}, 0, false},
+    }
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            length, ok := columnTypeLength(c.column)
+            if length != c.wantLength || ok != c.wantOk {
+                t.Errorf("columnTypeLength() = (%d, %v), want (%d, %v)", length, ok, c.wantLength, c.wantOk)
+            }
+        })
+    }
+}
@@ -0,0 +1,283 @@
+package mssql
+
+import (
+    "database/sql/driver"
+    "encoding/binary"
+    "math"
+    "math/big"
+    "reflect"
+    "time"
+
+    "gopkg.in/inf.v0"
+)
+
+// typeInfo is the wire-level type metadata needed to bind a parameter:
+// the TDS type id plus whatever size/precision/scale a variable-length
+// or numeric type requires in COLMETADATA.
+type typeInfo struct {
+    TypeId    uint8
+    Size      int
+    Prec      uint8
+    Scale     uint8
+    Collation [5]byte
+}
+
+// TypeMapper converts between Go values and the wire representation of
+// SQL Server types, covering both parameter binding and result decoding.
+// Applications that need higher-precision numerics (*big.Rat, *inf.Dec)
+// or custom time-zone handling can supply their own implementation in
+// place of DefaultTypeMapper, e.g.:
+//
+//     sql.Register("mssql-custom", &Driver{TypeMapper: myMapper})
+type TypeMapper interface {
+    // BindParam returns the TDS type metadata and encoded bytes to send
+    // on the wire for a parameter value.
+    BindParam(v interface{}) (typeInfo, []byte, error)
+
+    // DecodeValue converts a column's decoded buffer (as produced by the
+    // column's Reader, see readVarLen) into a database/sql driver.Value.
+    DecodeValue(column columnStruct, buf []byte) (driver.Value, error)
+}
+
+// DefaultTypeMapper is the TypeMapper used when a connection is not
+// configured with one of its own.
+var DefaultTypeMapper TypeMapper = defaultTypeMapper{}
+
+type defaultTypeMapper struct{}
+
+// IntervalEncoder is an opt-in hook for time.Duration values: if a value
+// passed as a parameter implements it, BindParam sends the type and
+// bytes it returns instead of the default BIGINT-nanoseconds encoding.
+type IntervalEncoder interface {
+    EncodeInterval() (typeInfo, []byte, error)
+}
+
+func (defaultTypeMapper) BindParam(v interface{}) (ti typeInfo, buf []byte, err error) {
+    switch val := v.(type) {
+    case IntervalEncoder:
+        return val.EncodeInterval()
+    case time.Time:
+        return bindTime(val)
+    case time.Duration:
+        ti = typeInfo{TypeId: typeInt8, Size: 8}
+        buf = make([]byte, 8)
+        binary.LittleEndian.PutUint64(buf, uint64(val.Nanoseconds()))
+    case *big.Rat:
+        return bindDecimal(ratToDecimal(val))
+    case *inf.Dec:
+        return bindDecimal(infDecToDecimal(val))
+    case Decimal:
+        return bindDecimal(val)
+    case [16]byte:
+        ti = typeInfo{TypeId: typeGuid, Size: 16}
+        buf = val[:]
+    case bool:
+        ti = typeInfo{TypeId: typeBitN, Size: 1}
+        buf = []byte{0}
+        if val {
+            buf[0] = 1
+        }
+    case int64:
+        ti = typeInfo{TypeId: typeIntN, Size: 8}
+        buf = make([]byte, 8)
+        binary.LittleEndian.PutUint64(buf, uint64(val))
+    case int:
+        return defaultTypeMapper{}.BindParam(int64(val))
+    case float64:
+        ti = typeInfo{TypeId: typeFltN, Size: 8}
+        buf = make([]byte, 8)
+        binary.LittleEndian.PutUint64(buf, math.Float64bits(val))
+    case string:
+        ti = typeInfo{TypeId: typeNVarChar}
+        buf, err = utf82ucs2.ConvertString(val)
+        ti.Size = len(buf)
+    case []byte:
+        ti = typeInfo{TypeId: typeBigVarBin, Size: len(val)}
+        buf = val
+    case nil:
+        ti = typeInfo{TypeId: typeNull}
+    default:
+        if guid, ok := asGuidArray(v); ok {
+            ti = typeInfo{TypeId: typeGuid, Size: 16}
+            buf = guid[:]
+            return
+        }
+        err = streamErrorf("mssql: TypeMapper: unsupported parameter type %T", v)
+    }
+    return
+}
+
+// asGuidArray recognizes values whose underlying type is a bare
+// [16]byte array, such as uuid.UUID from google/uuid or gofrs/uuid. A Go
+// type switch can't match those named types against the [16]byte case
+// above, so they're converted via reflection instead.
+func asGuidArray(v interface{}) (g [16]byte, ok bool) {
+    rv := reflect.ValueOf(v)
+    t := rv.Type()
+    if t.Kind() != reflect.Array || t.Len() != 16 || t.Elem().Kind() != reflect.Uint8 {
+        return
+    }
+    return rv.Convert(reflect.TypeOf(g)).Interface().([16]byte), true
+}
+
+// bindTime maps time.Time to DATETIME2(7), or to DATETIMEOFFSET(7) when
+// the value's Location is not UTC, so the server-side offset survives
+// the round trip.
+func bindTime(val time.Time) (ti typeInfo, buf []byte, err error) {
+    const scale = 7
+    days := daysSinceEpoch(val.Date())
+    sec := val.Hour()*3600 + val.Minute()*60 + val.Second()
+    ns := val.Nanosecond()
+    timebuf := encodeTimeInt(scale, sec, ns)
+    if val.Location() == time.UTC {
+        ti = typeInfo{TypeId: typeDateTime2N, Scale: scale}
+        buf = append(timebuf, encodeDateInt(days)...)
+        return
+    }
+    _, offset := val.Zone()
+    offmin := int16(offset / 60)
+    ti = typeInfo{TypeId: typeDateTimeOffsetN, Scale: scale}
+    buf = append(timebuf, encodeDateInt(days)...)
+    offbuf := make([]byte, 2)
+    binary.LittleEndian.PutUint16(offbuf, uint16(offmin))
+    buf = append(buf, offbuf...)
+    return
+}
+
+// encodeTimeInt is the inverse of decodeTimeInt.
+func encodeTimeInt(scale uint8, sec int, ns int) []byte {
+    acc := uint64(sec)*1000000000 + uint64(ns)
+    acc /= 100
+    for i := 0; i < 7-int(scale); i++ {
+        acc /= 10
+    }
+    size := 5
+    switch scale {
+    case 1, 2:
+        size = 3
+    case 3, 4:
+        size = 4
+    }
+    buf := make([]byte, size)
+    for i := 0; i < size; i++ {
+        buf[i] = byte(acc)
+        acc >>= 8
+    }
+    return buf
+}
+
+// encodeDateInt is the inverse of decodeDateInt.
+func encodeDateInt(days uint32) []byte {
+    return []byte{byte(days), byte(days >> 8), byte(days >> 16)}
+}
+
+// daysSinceEpoch counts the days between 0001-01-01 and the given
+// calendar date, the inverse of decodeDate's time.Date(1, 1, 1+days, ...).
+// It is computed from Unix seconds rather than time.Time.Sub, since Sub
+// returns a time.Duration and that overflows for any pair of times more
+// than ~292 years apart.
+func daysSinceEpoch(year int, month time.Month, day int) uint32 {
+    epoch := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+    midnight := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()
+    return uint32((midnight - epoch) / 86400)
+}
+
+// ratToDecimal converts a *big.Rat to the package's Decimal, at a default
+// DECIMAL(38, 8) precision/scale for callers (e.g. plain BindParam) with
+// no narrower destination column to match.
+func ratToDecimal(r *big.Rat) Decimal {
+    return ratToDecimalScaled(r, 38, 8)
+}
+
+// ratToDecimalScaled is ratToDecimal generalized to an arbitrary
+// destination precision/scale, for callers (e.g. bulk insert) that know
+// the actual column they're binding against.
+func ratToDecimalScaled(r *big.Rat, prec, scale uint8) Decimal {
+    mul := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+    scaled := new(big.Int).Mul(r.Num(), mul)
+    scaled.Quo(scaled, r.Denom())
+    return bigIntToDecimal(scaled, prec, scale)
+}
+
+// infDecToDecimal converts a *inf.Dec to the package's Decimal at its own
+// scale and a default DECIMAL(38, ...) precision.
+func infDecToDecimal(d *inf.Dec) Decimal {
+    return bigIntToDecimal(d.UnscaledBig(), 38, uint8(d.Scale()))
+}
+
+// infDecToDecimalScaled is infDecToDecimal generalized to an arbitrary
+// destination precision/scale: d is rescaled first, since its own scale
+// may not match the column's.
+func infDecToDecimalScaled(d *inf.Dec, prec, scale uint8) Decimal {
+    rescaled := new(inf.Dec).Round(d, inf.Scale(scale), inf.RoundHalfEven)
+    return bigIntToDecimal(rescaled.UnscaledBig(), prec, scale)
+}
+
+func bigIntToDecimal(v *big.Int, prec, scale uint8) Decimal {
+    dec := Decimal{positive: v.Sign() >= 0, prec: prec, scale: scale}
+    mag := new(big.Int).Abs(v).Bytes() // big-endian
+    for i, j := 0, len(mag)-1; j >= 0 && i/4 < len(dec.integer); i, j = i+1, j-1 {
+        dec.integer[i/4] |= uint32(mag[j]) << (uint(i%4) * 8)
+    }
+    return dec
+}
+
+// bindDecimal maps the package's Decimal to DECIMAL(prec, scale).
+func bindDecimal(dec Decimal) (ti typeInfo, buf []byte, err error) {
+    ti = typeInfo{TypeId: typeDecimalN, Prec: dec.prec, Scale: dec.scale}
+    buf = make([]byte, 17)
+    if dec.positive {
+        buf[0] = 1
+    }
+    for i, word := range dec.integer {
+        binary.LittleEndian.PutUint32(buf[1+i*4:], word)
+    }
+    ti.Size = len(buf)
+    return
+}
+
+func (defaultTypeMapper) DecodeValue(column columnStruct, buf []byte) (driver.Value, error) {
+    if buf == nil {
+        return nil, nil
+    }
+    switch column.TypeId {
+    case typeDateTim4:
+        return decodeDateTim4(buf), nil
+    case typeDateTime:
+        return decodeDateTime(buf), nil
+    case typeDateN:
+        return decodeDate(buf), nil
+    case typeTimeN:
+        return decodeTime(column, buf), nil
+    case typeDateTime2N:
+        return decodeDateTime2(column.Scale, buf), nil
+    case typeDateTimeOffsetN:
+        return decodeDateTimeOffset(column.Scale, buf), nil
+    case typeMoney:
+        return decodeMoney(buf), nil
+    case typeMoney4:
+        return decodeMoney4(buf), nil
+    case typeMoneyN:
+        switch len(buf) {
+        case 4:
+            return decodeMoney4(buf), nil
+        default:
+            return decodeMoney(buf), nil
+        }
+    case typeDecimal, typeNumeric, typeDecimalN, typeNumericN:
+        return decodeDecimal(column, buf), nil
+    case typeGuid:
+        // driver.Value permits only int64, float64, bool, []byte, string,
+        // time.Time and nil, so the decoded [16]byte is formatted as a
+        // UUID string rather than returned as-is.
+        return guidToString(decodeGuid(buf)), nil
+    case typeChar, typeVarChar, typeBigChar, typeBigVarChar, typeText:
+        return decodeChar(column, buf), nil
+    case typeNChar, typeNVarChar, typeNText:
+        return decodeNChar(column, buf)
+    case typeXml:
+        return decodeXml(column, buf)
+    default:
+        return buf, nil
+    }
+}
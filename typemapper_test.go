@@ -0,0 +1,201 @@
+package mssql
+
+import (
+    "encoding/binary"
+    "math/big"
+    "testing"
+    "time"
+
+    "gopkg.in/inf.v0"
+)
+
+// TestBindTimeDateTime2RoundTrip exercises bindTime/decodeDateTime2 across
+// a range of years, including ones far enough apart that computing days
+// via time.Time.Sub (rather than from the calendar date) would overflow
+// time.Duration and silently produce the same wrong day count for all of
+// them.
+func TestBindTimeDateTime2RoundTrip(t *testing.T) {
+    cases := []time.Time{
+        time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC),
+        time.Date(1900, 3, 14, 1, 2, 3, 0, time.UTC),
+        time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC),
+        time.Date(2026, 7, 27, 12, 30, 0, 0, time.UTC),
+        time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC),
+    }
+    for _, want := range cases {
+        ti, buf, err := bindTime(want)
+        if err != nil {
+            t.Fatalf("bindTime(%v): %v", want, err)
+        }
+        if ti.TypeId != typeDateTime2N {
+            t.Fatalf("bindTime(%v): TypeId = %#x, want typeDateTime2N", want, ti.TypeId)
+        }
+        got := decodeDateTime2(ti.Scale, buf)
+        if !got.Equal(want) {
+            t.Errorf("bindTime/decodeDateTime2(%v) round-tripped to %v", want, got)
+        }
+    }
+}
+
+// TestBindTimeDateTimeOffsetRoundTrip checks that a non-UTC Location
+// switches bindTime to DATETIMEOFFSET and preserves both the instant and
+// the wall-clock offset.
+func TestBindTimeDateTimeOffsetRoundTrip(t *testing.T) {
+    loc := time.FixedZone("", -7*3600)
+    want := time.Date(2026, 7, 27, 9, 15, 30, 0, loc)
+    ti, buf, err := bindTime(want)
+    if err != nil {
+        t.Fatalf("bindTime: %v", err)
+    }
+    if ti.TypeId != typeDateTimeOffsetN {
+        t.Fatalf("TypeId = %#x, want typeDateTimeOffsetN", ti.TypeId)
+    }
+    got := decodeDateTimeOffset(ti.Scale, buf)
+    if !got.Equal(want) {
+        t.Errorf("round-tripped instant %v, want %v", got, want)
+    }
+    if _, gotOffset := got.Zone(); gotOffset != -7*3600 {
+        t.Errorf("round-tripped offset %d, want %d", gotOffset, -7*3600)
+    }
+}
+
+// TestBindParamDecimalRoundTrip checks BindParam/decodeDecimal for the
+// package's own Decimal type, the only one of BindParam's numeric paths
+// bigIntToDecimal feeds directly rather than through a rat/inf.Dec
+// conversion first.
+func TestBindParamDecimalRoundTrip(t *testing.T) {
+    want := bigIntToDecimal(big.NewInt(123456), 10, 3)
+    ti, buf, err := DefaultTypeMapper.BindParam(want)
+    if err != nil {
+        t.Fatalf("BindParam: %v", err)
+    }
+    if ti.TypeId != typeDecimalN || ti.Prec != 10 || ti.Scale != 3 {
+        t.Fatalf("BindParam: got %+v", ti)
+    }
+    got := decodeDecimal(columnStruct{Prec: ti.Prec, Scale: ti.Scale}, buf)
+    if got != want {
+        t.Errorf("decodeDecimal round-tripped to %+v, want %+v", got, want)
+    }
+}
+
+// TestBindParamRatRoundTrip checks that a *big.Rat parameter is bound as
+// a DECIMAL(38, 8) scaled to 8 decimal places.
+func TestBindParamRatRoundTrip(t *testing.T) {
+    r := big.NewRat(1, 3) // 0.33333333 at scale 8
+    ti, buf, err := DefaultTypeMapper.BindParam(r)
+    if err != nil {
+        t.Fatalf("BindParam: %v", err)
+    }
+    if ti.TypeId != typeDecimalN || ti.Scale != 8 {
+        t.Fatalf("BindParam: got %+v", ti)
+    }
+    dec := decodeDecimal(columnStruct{Prec: ti.Prec, Scale: ti.Scale}, buf)
+    if !dec.positive || dec.integer[0] != 33333333 {
+        t.Errorf("decodeDecimal: got %+v, want integer[0]=33333333", dec)
+    }
+}
+
+// TestBindParamInfDecRoundTrip checks that an *inf.Dec parameter is bound
+// at its own scale.
+func TestBindParamInfDecRoundTrip(t *testing.T) {
+    d := inf.NewDec(1234, 2) // 12.34
+    ti, buf, err := DefaultTypeMapper.BindParam(d)
+    if err != nil {
+        t.Fatalf("BindParam: %v", err)
+    }
+    if ti.TypeId != typeDecimalN || ti.Scale != 2 {
+        t.Fatalf("BindParam: got %+v", ti)
+    }
+    dec := decodeDecimal(columnStruct{Prec: ti.Prec, Scale: ti.Scale}, buf)
+    if !dec.positive || dec.integer[0] != 1234 {
+        t.Errorf("decodeDecimal: got %+v, want integer[0]=1234", dec)
+    }
+}
+
+// uuidT stands in for a third-party named [16]byte array type (e.g.
+// uuid.UUID), which asGuidArray recognizes via reflection since a Go
+// type switch can't match it directly against the [16]byte case.
+type uuidT [16]byte
+
+// TestBindParamGuid checks both ways BindParam recognizes a GUID
+// parameter: the literal [16]byte case, and a named array type matched
+// through asGuidArray.
+func TestBindParamGuid(t *testing.T) {
+    var raw [16]byte
+    for i := range raw {
+        raw[i] = byte(i)
+    }
+
+    t.Run("[16]byte", func(t *testing.T) {
+        ti, buf, err := DefaultTypeMapper.BindParam(raw)
+        if err != nil {
+            t.Fatalf("BindParam: %v", err)
+        }
+        if ti.TypeId != typeGuid || ti.Size != 16 {
+            t.Fatalf("BindParam: got %+v", ti)
+        }
+        if string(buf) != string(raw[:]) {
+            t.Errorf("BindParam: buf = %v, want %v", buf, raw)
+        }
+    })
+
+    t.Run("named array type", func(t *testing.T) {
+        ti, buf, err := DefaultTypeMapper.BindParam(uuidT(raw))
+        if err != nil {
+            t.Fatalf("BindParam: %v", err)
+        }
+        if ti.TypeId != typeGuid || ti.Size != 16 {
+            t.Fatalf("BindParam: got %+v", ti)
+        }
+        if string(buf) != string(raw[:]) {
+            t.Errorf("BindParam: buf = %v, want %v", buf, raw)
+        }
+    })
+}
+
+// TestDecodeValueGuid checks that DecodeValue formats a GUID column's
+// wire bytes as a driver.Value-legal string rather than returning the
+// decoded [16]byte array directly.
+func TestDecodeValueGuid(t *testing.T) {
+    buf := []byte{
+        0x67, 0x45, 0x23, 0x01, 0xAB, 0x89, 0xEF, 0xCD,
+        0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF,
+    }
+    column := columnStruct{TypeId: typeGuid}
+    got, err := DefaultTypeMapper.DecodeValue(column, buf)
+    if err != nil {
+        t.Fatalf("DecodeValue: %v", err)
+    }
+    want := "01234567-89AB-CDEF-0123-456789ABCDEF"
+    if got != want {
+        t.Errorf("DecodeValue = %v, want %v", got, want)
+    }
+}
+
+func TestDecodeMoney(t *testing.T) {
+    // 100.0000 as a scaled int64 (10^-4), split into high/low 32-bit
+    // halves per decodeMoney's wire format.
+    buf := make([]byte, 8)
+    binary.LittleEndian.PutUint32(buf[0:4], 0)
+    binary.LittleEndian.PutUint32(buf[4:8], 1000000)
+    dec := decodeMoney(buf)
+    if !dec.positive || dec.scale != 4 || dec.prec != 19 {
+        t.Fatalf("decodeMoney: got %+v", dec)
+    }
+    if dec.integer[0] != 1000000 {
+        t.Errorf("decodeMoney: integer[0] = %d, want 1000000", dec.integer[0])
+    }
+}
+
+func TestDecodeMoney4Negative(t *testing.T) {
+    buf := make([]byte, 4)
+    var v int32 = -1234567
+    binary.LittleEndian.PutUint32(buf, uint32(v))
+    dec := decodeMoney4(buf)
+    if dec.positive || dec.scale != 4 || dec.prec != 10 {
+        t.Fatalf("decodeMoney4: got %+v", dec)
+    }
+    if dec.integer[0] != 1234567 {
+        t.Errorf("decodeMoney4: integer[0] = %d, want 1234567", dec.integer[0])
+    }
+}
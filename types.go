@@ -1,8 +1,10 @@
 package mssql
 
 import (
+    "bytes"
     "io"
     "encoding/binary"
+    "fmt"
     "math"
     "time"
 )
@@ -117,8 +119,138 @@ func readShortLenType(column *columnStruct, r io.Reader) (res []byte, err error)
     return column.Buffer[:size], nil
 }
 
+// TEXT/NTEXT/IMAGE row data is prefixed with a variable-length TEXTPTR
+// followed by an 8-byte TIMESTAMP and a 4-byte data length.
+// http://msdn.microsoft.com/en-us/library/dd304523.aspx
 func readLongLenType(column *columnStruct, r io.Reader) (res []byte, err error) {
-    panic("Not implemented")
+    var textptrsize uint8
+    err = binary.Read(r, binary.LittleEndian, &textptrsize); if err != nil {
+        return
+    }
+    if textptrsize == 0 {
+        // null textptr means the value itself is NULL
+        return nil, nil
+    }
+    textptr := make([]byte, textptrsize)
+    _, err = io.ReadFull(r, textptr); if err != nil {
+        return
+    }
+    var timestamp [8]byte
+    _, err = io.ReadFull(r, timestamp[:]); if err != nil {
+        return
+    }
+    var datalen int32
+    err = binary.Read(r, binary.LittleEndian, &datalen); if err != nil {
+        return
+    }
+    buf := make([]byte, datalen)
+    _, err = io.ReadFull(r, buf); if err != nil {
+        return
+    }
+    return buf, nil
+}
+
+// PLP (partially length-prefixed) is the wire format used for the *(MAX)
+// types (varchar(max), nvarchar(max), varbinary(max)) and for XML: rather
+// than a single length prefix, the value is an 8-byte total length (or
+// one of the sentinels below) followed by a sequence of chunks, each with its own 4-byte length and a
+// final zero-length chunk marking the end.
+// http://msdn.microsoft.com/en-us/library/dd340469.aspx
+const (
+    unknownPlpLen uint64 = 0xfffffffffffffffe
+    plpNullLen    uint64 = 0xffffffffffffffff
+)
+
+// PlpReader streams the chunks of a PLP value as a single io.Reader so
+// that callers with large XML/VARCHAR(MAX)/VARBINARY(MAX) values can
+// consume them incrementally instead of buffering the whole value.
+type PlpReader struct {
+    r         io.Reader
+    chunkleft uint32
+    isNull    bool
+}
+
+func startReadPLPType(r io.Reader) (*PlpReader, error) {
+    var size uint64
+    err := binary.Read(r, binary.LittleEndian, &size); if err != nil {
+        return nil, err
+    }
+    res := &PlpReader{r: r, isNull: size == plpNullLen}
+    return res, nil
+}
+
+func (p *PlpReader) Read(buf []byte) (n int, err error) {
+    if p.isNull {
+        return 0, io.EOF
+    }
+    if p.chunkleft == 0 {
+        err = binary.Read(p.r, binary.LittleEndian, &p.chunkleft); if err != nil {
+            return
+        }
+        if p.chunkleft == 0 {
+            return 0, io.EOF
+        }
+    }
+    if uint32(len(buf)) > p.chunkleft {
+        buf = buf[:p.chunkleft]
+    }
+    n, err = p.r.Read(buf)
+    p.chunkleft -= uint32(n)
+    return
+}
+
+func readPLPType(column *columnStruct, r io.Reader) (res []byte, err error) {
+    rdr, err := startReadPLPType(r); if err != nil {
+        return
+    }
+    if rdr.isNull {
+        return nil, nil
+    }
+    var buf bytes.Buffer
+    _, err = io.Copy(&buf, rdr); if err != nil {
+        return
+    }
+    return buf.Bytes(), nil
+}
+
+// readBVarChar reads a one-byte-length-prefixed UCS-2 string (B_VARCHAR).
+func readBVarChar(r io.Reader) (string, error) {
+    var size uint8
+    if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+        return "", err
+    }
+    return readUcs2(r, int(size))
+}
+
+// readUsVarChar reads a two-byte-length-prefixed UCS-2 string (US_VARCHAR).
+func readUsVarChar(r io.Reader) (string, error) {
+    var size uint16
+    if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+        return "", err
+    }
+    return readUcs2(r, int(size))
+}
+
+func readUcs2(r io.Reader, numchars int) (string, error) {
+    buf := make([]byte, numchars*2)
+    if _, err := io.ReadFull(r, buf); err != nil {
+        return "", err
+    }
+    return ucs22utf8.ConvertString(string(buf))
+}
+
+// skipXmlSchemaCollection reads past the optional XML schema collection
+// name carried in XMLTYPE column metadata. It has no bearing on how the
+// value itself is decoded, so the strings are discarded.
+func skipXmlSchemaCollection(r io.Reader) (err error) {
+    if _, err = readBVarChar(r); err != nil { // dbname
+        return
+    }
+    if _, err = readBVarChar(r); err != nil { // owning schema
+        return
+    }
+    _, err = readUsVarChar(r) // xml schema collection name
+    return
 }
 
 func readVarLen(column *columnStruct, r io.Reader) (err error) {
@@ -171,8 +303,23 @@ func readVarLen(column *columnStruct, r io.Reader) (err error) {
             }
         }
         column.Reader = readByteLenType
+    case typeXml:
+        // XMLTYPE has no preceding ushort size: it is carried as an
+        // optional schema-collection reference followed by an always-PLP
+        // encoded value.
+        var schemapresent uint8
+        err = binary.Read(r, binary.LittleEndian, &schemapresent); if err != nil {
+            return
+        }
+        if schemapresent != 0 {
+            err = skipXmlSchemaCollection(r); if err != nil {
+                return
+            }
+        }
+        column.Size = 0xffff
+        column.Reader = readPLPType
     case typeBigVarBin, typeBigVarChar, typeBigBinary, typeBigChar,
-            typeNVarChar, typeNChar, typeXml, typeUdt:
+            typeNVarChar, typeNChar, typeUdt:
         // short len types
         var ushortsize uint16
         err = binary.Read(r, binary.LittleEndian, &ushortsize); if err != nil {
@@ -184,11 +331,10 @@ func readVarLen(column *columnStruct, r io.Reader) (err error) {
             column.Collation, err = readCollation(r); if err != nil {
                 return
             }
-        case typeXml:
-            panic("XMLTYPE not implemented")
         }
         if column.Size == 0xffff {
-            panic("PARTLENTYPE not yet supported")
+            // PARTLENTYPE: varchar(max)/nvarchar(max)/varbinary(max)
+            column.Reader = readPLPType
         } else {
             column.Buffer = make([]byte, column.Size)
             column.Reader = readShortLenType
@@ -204,9 +350,12 @@ func readVarLen(column *columnStruct, r io.Reader) (err error) {
             column.Collation, err = readCollation(r); if err != nil {
                 return
             }
-        case typeXml:
-            panic("XMLTYPE not implemented")
         }
+        // longsize here is TYPE_INFO's declared capacity, not a per-row
+        // bound: TEXT/NTEXT/IMAGE have no real max length, so the actual
+        // size is only known per-row from readLongLenType's own datalen
+        // preamble. Preallocating column.Buffer from it at metadata-parse
+        // time risks a huge, useless allocation; leave it unset.
         column.Size = int(longsize)
         column.Reader = readLongLenType
     default:
@@ -216,12 +365,36 @@ func readVarLen(column *columnStruct, r io.Reader) (err error) {
 }
 
 
-func decodeMoney(buf []byte) int {
-    panic("Not implemented")
+// MONEY is a signed 8-byte fixed-point value scaled by 10^-4, stored on
+// the wire as the high 4 bytes followed by the low 4 bytes.
+// http://msdn.microsoft.com/en-us/library/dd357829.aspx
+func decodeMoney(buf []byte) Decimal {
+    high := int32(binary.LittleEndian.Uint32(buf[0:4]))
+    low := binary.LittleEndian.Uint32(buf[4:8])
+    value := int64(high) << 32 | int64(low)
+    return decodeMoneyValue(value, 19)
 }
 
-func decodeMoney4(buf []byte) int {
-    panic("Not implemented")
+// SMALLMONEY is a signed 4-byte fixed-point value scaled by 10^-4.
+// http://msdn.microsoft.com/en-us/library/dd357829.aspx
+func decodeMoney4(buf []byte) Decimal {
+    value := int64(int32(binary.LittleEndian.Uint32(buf)))
+    return decodeMoneyValue(value, 10)
+}
+
+func decodeMoneyValue(value int64, prec uint8) Decimal {
+    dec := Decimal{
+        positive: value >= 0,
+        prec: prec,
+        scale: 4,
+    }
+    magnitude := uint64(value)
+    if !dec.positive {
+        magnitude = uint64(-value)
+    }
+    dec.integer[0] = uint32(magnitude)
+    dec.integer[1] = uint32(magnitude >> 32)
+    return dec
 }
 
 func decodeGuid(buf []byte) (res [16]byte) {
@@ -229,6 +402,20 @@ func decodeGuid(buf []byte) (res [16]byte) {
     return
 }
 
+// guidToString formats a UNIQUEIDENTIFIER's wire bytes as the standard
+// "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx" representation. The wire format
+// stores the first three fields little-endian, so they're byte-reversed;
+// the last two fields are already in display order.
+// http://msdn.microsoft.com/en-us/library/dd339813.aspx
+func guidToString(g [16]byte) string {
+    return fmt.Sprintf("%X-%X-%X-%X-%X",
+        []byte{g[3], g[2], g[1], g[0]},
+        []byte{g[5], g[4]},
+        []byte{g[7], g[6]},
+        g[8:10],
+        g[10:16])
+}
+
 func decodeDecimal(column columnStruct, buf []byte) Decimal {
     var sign uint8
     sign = buf[0]
@@ -281,8 +468,15 @@ func decodeDateTime2(scale uint8, buf []byte) time.Time {
     return time.Date(1, 1, 1 + days, 0, 0, sec, ns, time.UTC)
 }
 
-func decodeDateTimeOffset(buf []byte) int {
-    panic("Not implemented")
+// DATETIMEOFFSET carries the same time/date encoding as DATETIME2,
+// expressed in the offset timezone, followed by a signed 16-bit offset
+// in minutes from UTC.
+func decodeDateTimeOffset(scale uint8, buf []byte) time.Time {
+    timesize := len(buf) - 2 - 3
+    sec, ns := decodeTimeInt(scale, buf[:timesize])
+    days := decodeDateInt(buf[timesize : timesize+3])
+    offset := int(int16(binary.LittleEndian.Uint16(buf[timesize+3:])))
+    return time.Date(1, 1, 1 + days, 0, 0, sec, ns, time.FixedZone("", offset * 60))
 }
 
 func decodeChar(column columnStruct, buf []byte) string {
@@ -293,8 +487,11 @@ func decodeNChar(column columnStruct, buf []byte) (string, error) {
     return ucs22utf8.ConvertString(string(buf))
 }
 
-func decodeXml(column columnStruct, buf []byte) int {
-    panic("Not implemented")
+func decodeXml(column columnStruct, buf []byte) (string, error) {
+    if buf == nil {
+        return "", nil
+    }
+    return ucs22utf8.ConvertString(string(buf))
 }
 
 func decodeUdt(column columnStruct, buf []byte) int {
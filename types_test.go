@@ -0,0 +1,83 @@
+package mssql
+
+import (
+    "bytes"
+    "io"
+    "reflect"
+    "testing"
+)
+
+// funcPointer returns a comparable identity for a column.Reader value,
+// since Go func values can only be compared against nil directly.
+func funcPointer(f func(*columnStruct, io.Reader) ([]byte, error)) uintptr {
+    return reflect.ValueOf(f).Pointer()
+}
+
+// TestReadVarLenTypeIds drives readVarLen through every TypeId branch it
+// handles, checking the column metadata it derives from TYPE_INFO bytes
+// shaped like what a real COLMETADATA token carries for that type.
+func TestReadVarLenTypeIds(t *testing.T) {
+    collation := make([]byte, 5)
+
+    cases := []struct {
+        name       string
+        typeId     uint8
+        wire       []byte
+        wantSize   int
+        wantReader uintptr
+    }{
+        {"DateN", typeDateN, nil, 3, funcPointer(readByteLenType)},
+        {"TimeN scale7", typeTimeN, []byte{7}, 5, funcPointer(readByteLenType)},
+        {"DateTime2N scale7", typeDateTime2N, []byte{7}, 8, funcPointer(readByteLenType)},
+        {"DateTimeOffsetN scale7", typeDateTimeOffsetN, []byte{7}, 10, funcPointer(readByteLenType)},
+        {"IntN", typeIntN, []byte{4}, 4, funcPointer(readByteLenType)},
+        {"DecimalN", typeDecimalN, []byte{17, 38, 10}, 17, funcPointer(readByteLenType)},
+        {"BigVarBin normal", typeBigVarBin, []byte{50, 0}, 50, funcPointer(readShortLenType)},
+        {"BigVarBin max (PLP)", typeBigVarBin, []byte{0xff, 0xff}, 0xffff, funcPointer(readPLPType)},
+        {"NVarChar normal", typeNVarChar, append([]byte{50, 0}, collation...), 50, funcPointer(readShortLenType)},
+        {"NVarChar max (PLP)", typeNVarChar, append([]byte{0xff, 0xff}, collation...), 0xffff, funcPointer(readPLPType)},
+        {"Xml no schema", typeXml, []byte{0}, 0xffff, funcPointer(readPLPType)},
+        {"Image", typeImage, []byte{0x10, 0, 0, 0}, 0x10, funcPointer(readLongLenType)},
+        {"Text with collation", typeText, append([]byte{0x20, 0, 0, 0}, collation...), 0x20, funcPointer(readLongLenType)},
+    }
+
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            var column columnStruct
+            column.TypeId = c.typeId
+            r := bytes.NewReader(c.wire)
+            if err := readVarLen(&column, r); err != nil {
+                t.Fatalf("readVarLen: %v", err)
+            }
+            if column.Size != c.wantSize {
+                t.Errorf("Size = %#x, want %#x", column.Size, c.wantSize)
+            }
+            if funcPointer(column.Reader) != c.wantReader {
+                t.Errorf("Reader = %p, want the expected decoder", column.Reader)
+            }
+        })
+    }
+}
+
+func TestReadVarLenXmlWithSchema(t *testing.T) {
+    var column columnStruct
+    column.TypeId = typeXml
+    // schemapresent=1, then an empty dbname and owning-schema B_VARCHAR
+    // (1-byte length each) and an empty collection-name US_VARCHAR
+    // (2-byte length).
+    r := bytes.NewReader([]byte{1, 0, 0, 0, 0})
+    if err := readVarLen(&column, r); err != nil {
+        t.Fatalf("readVarLen: %v", err)
+    }
+    if column.Size != 0xffff || funcPointer(column.Reader) != funcPointer(readPLPType) {
+        t.Errorf("got Size=%#x Reader=%p", column.Size, column.Reader)
+    }
+}
+
+func TestReadVarLenInvalidType(t *testing.T) {
+    var column columnStruct
+    column.TypeId = 0xde
+    if err := readVarLen(&column, bytes.NewReader(nil)); err == nil {
+        t.Fatal("expected an error for an unrecognized TypeId")
+    }
+}